@@ -0,0 +1,82 @@
+package mail
+
+// Config.Auth accepts any net/smtp.Auth, which already makes PLAIN
+// (smtp.PlainAuth) and CRAM-MD5 (smtp.CRAMMD5Auth) pluggable without any
+// wrapper from this package. LoginAuth and XOAUTH2Auth below fill the two
+// mechanisms the standard library doesn't provide: LOGIN, still required by
+// Office 365 and older servers, and XOAUTH2, needed to authenticate to
+// Gmail/Google Workspace with OAuth2 access tokens now that app passwords
+// are being phased out.
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+
+	"golang.org/x/oauth2"
+)
+
+// loginAuth implements the LOGIN authentication mechanism: the server
+// prompts for "Username:" then "Password:" instead of PLAIN's single
+// combined response.
+type loginAuth struct {
+	username, password string
+}
+
+// LoginAuth returns an smtp.Auth that implements the LOGIN mechanism.
+func LoginAuth(username, password string) smtp.Auth {
+	return &loginAuth{username: username, password: password}
+}
+
+func (a *loginAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("mail: unexpected LOGIN server prompt %q", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism used by Gmail/Google
+// Workspace (and supported by some other providers) to authenticate with an
+// OAuth2 access token instead of a password.
+type xoauth2Auth struct {
+	username string
+	tokens   oauth2.TokenSource
+}
+
+// XOAUTH2Auth returns an smtp.Auth that authenticates as username using an
+// access token drawn from tokens. Passing an oauth2.TokenSource (rather than
+// a bare token string) means a TokenSource backed by a refresh token will
+// transparently renew expired access tokens between sends.
+func XOAUTH2Auth(username string, tokens oauth2.TokenSource) smtp.Auth {
+	return &xoauth2Auth{username: username, tokens: tokens}
+}
+
+func (a *xoauth2Auth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	token, err := a.tokens.Token()
+	if err != nil {
+		return "", nil, fmt.Errorf("mail: fetch oauth2 token: %w", err)
+	}
+	resp := fmt.Sprintf("user=%s\x01auth=Bearer %s\x01\x01", a.username, token.AccessToken)
+	return "XOAUTH2", []byte(resp), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	// On failure the server sends a JSON error payload (already base64-decoded
+	// by net/smtp). Returning an error makes net/smtp cancel the exchange
+	// with "*" and report the failure.
+	return nil, errors.New("mail: xoauth2 authentication failed: " + string(fromServer))
+}
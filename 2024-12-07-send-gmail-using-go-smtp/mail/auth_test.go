@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestLoginAuth(t *testing.T) {
+	auth := LoginAuth("user@example.com", "s3cr3t")
+
+	proto, _, err := auth.Start(nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if proto != "LOGIN" {
+		t.Errorf("proto = %q, want LOGIN", proto)
+	}
+
+	resp, err := auth.Next([]byte("Username:"), true)
+	if err != nil || string(resp) != "user@example.com" {
+		t.Errorf("Next(Username:) = %q, %v", resp, err)
+	}
+
+	resp, err = auth.Next([]byte("Password:"), true)
+	if err != nil || string(resp) != "s3cr3t" {
+		t.Errorf("Next(Password:) = %q, %v", resp, err)
+	}
+
+	if _, err := auth.Next([]byte("Unexpected:"), true); err == nil {
+		t.Error("expected error on unexpected server prompt")
+	}
+
+	if resp, err := auth.Next(nil, false); resp != nil || err != nil {
+		t.Errorf("Next(more=false) = %q, %v, want nil, nil", resp, err)
+	}
+}
+
+type stubTokenSource struct {
+	token *oauth2.Token
+	err   error
+}
+
+func (s stubTokenSource) Token() (*oauth2.Token, error) {
+	return s.token, s.err
+}
+
+func TestXOAUTH2Auth(t *testing.T) {
+	auth := XOAUTH2Auth("user@example.com", stubTokenSource{token: &oauth2.Token{AccessToken: "tok123"}})
+
+	proto, resp, err := auth.Start(nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if proto != "XOAUTH2" {
+		t.Errorf("proto = %q, want XOAUTH2", proto)
+	}
+	want := "user=user@example.com\x01auth=Bearer tok123\x01\x01"
+	if string(resp) != want {
+		t.Errorf("resp = %q, want %q", resp, want)
+	}
+}
+
+func TestXOAUTH2AuthTokenError(t *testing.T) {
+	auth := XOAUTH2Auth("user@example.com", stubTokenSource{err: errors.New("refresh failed")})
+
+	if _, _, err := auth.Start(nil); err == nil || !strings.Contains(err.Error(), "refresh failed") {
+		t.Errorf("Start error = %v, want wrapping 'refresh failed'", err)
+	}
+}
+
+func TestXOAUTH2AuthNextOnFailure(t *testing.T) {
+	auth := XOAUTH2Auth("user@example.com", stubTokenSource{token: &oauth2.Token{AccessToken: "tok"}})
+
+	if _, err := auth.Next([]byte(`{"status":"401"}`), true); err == nil {
+		t.Error("expected error when server reports authentication failure")
+	}
+}
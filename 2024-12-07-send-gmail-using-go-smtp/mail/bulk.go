@@ -0,0 +1,258 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/textproto"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// BulkRecipient is one destination in a bulk send: an address plus the
+// fields its message template is rendered with.
+type BulkRecipient struct {
+	Email string
+	Data  map[string]any
+}
+
+// BulkTemplate is the shared shape of a bulk message. Subject and Text are
+// parsed as text/template; HTML is parsed as html/template so that recipient
+// data is escaped appropriately. Leave HTML empty to send text-only.
+type BulkTemplate struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// BulkOptions controls how SendBulk delivers a batch of messages.
+type BulkOptions struct {
+	// Workers bounds how many SMTP connections are opened in parallel.
+	// Defaults to 1 (a single reused connection, sent sequentially).
+	Workers int
+	// MaxRetries is how many additional attempts a message gets after a 4xx
+	// (transient) SMTP reply. Defaults to 0 (no retry).
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles on each
+	// subsequent attempt. Defaults to 1 second.
+	RetryBackoff time.Duration
+	// DryRun renders each message and writes it to Output instead of
+	// sending it.
+	DryRun bool
+	// Output receives rendered messages when DryRun is set. Required if
+	// DryRun is true.
+	Output io.Writer
+}
+
+func (o BulkOptions) workers() int {
+	if o.Workers < 1 {
+		return 1
+	}
+	return o.Workers
+}
+
+func (o BulkOptions) retryBackoff() time.Duration {
+	if o.RetryBackoff <= 0 {
+		return time.Second
+	}
+	return o.RetryBackoff
+}
+
+// BulkResult reports the outcome of sending to one recipient. It is tagged
+// for JSON so a slice of results can be logged or piped to another tool.
+type BulkResult struct {
+	Email    string `json:"email"`
+	Success  bool   `json:"success"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// parsedBulkTemplate holds the compiled text/template and (optional)
+// html/template for a BulkTemplate.
+type parsedBulkTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+func parseBulkTemplate(tmpl BulkTemplate) (*parsedBulkTemplate, error) {
+	subject, err := texttemplate.New("subject").Parse(tmpl.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("parse subject template: %w", err)
+	}
+
+	p := &parsedBulkTemplate{subject: subject}
+
+	if tmpl.Text != "" {
+		p.text, err = texttemplate.New("text").Parse(tmpl.Text)
+		if err != nil {
+			return nil, fmt.Errorf("parse text template: %w", err)
+		}
+	}
+	if tmpl.HTML != "" {
+		p.html, err = htmltemplate.New("html").Parse(tmpl.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("parse html template: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// render produces the Message for one recipient.
+func (p *parsedBulkTemplate) render(from string, r BulkRecipient) (*Message, error) {
+	var subject strings.Builder
+	if err := p.subject.Execute(&subject, r.Data); err != nil {
+		return nil, fmt.Errorf("render subject: %w", err)
+	}
+
+	msg := &Message{
+		From:    from,
+		To:      []string{r.Email},
+		Subject: subject.String(),
+	}
+
+	if p.text != nil {
+		var body strings.Builder
+		if err := p.text.Execute(&body, r.Data); err != nil {
+			return nil, fmt.Errorf("render text body: %w", err)
+		}
+		msg.TextBody = body.String()
+	}
+	if p.html != nil {
+		var body strings.Builder
+		if err := p.html.Execute(&body, r.Data); err != nil {
+			return nil, fmt.Errorf("render html body: %w", err)
+		}
+		msg.HTMLBody = body.String()
+	}
+	return msg, nil
+}
+
+// SendBulk renders tmpl once per recipient and delivers the resulting
+// messages, reusing up to opts.Workers SMTP connections across the batch.
+// Results are returned in the same order as recipients.
+func (c *Client) SendBulk(ctx context.Context, from string, tmpl BulkTemplate, recipients []BulkRecipient, opts BulkOptions) ([]BulkResult, error) {
+	parsed, err := parseBulkTemplate(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("mail: %w", err)
+	}
+
+	results := make([]BulkResult, len(recipients))
+
+	if opts.DryRun {
+		if opts.Output == nil {
+			return nil, fmt.Errorf("mail: BulkOptions.DryRun requires Output")
+		}
+		for i, r := range recipients {
+			msg, err := parsed.render(from, r)
+			if err != nil {
+				results[i] = BulkResult{Email: r.Email, Error: err.Error()}
+				continue
+			}
+			raw, err := msg.Build()
+			if err != nil {
+				results[i] = BulkResult{Email: r.Email, Error: err.Error()}
+				continue
+			}
+			fmt.Fprintf(opts.Output, "--- %s ---\n%s\n", r.Email, raw)
+			results[i] = BulkResult{Email: r.Email, Success: true}
+		}
+		return results, nil
+	}
+
+	type job struct {
+		index     int
+		recipient BulkRecipient
+	}
+
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+
+	for w := 0; w < opts.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var s *session
+			defer func() {
+				if s != nil {
+					s.Close()
+				}
+			}()
+
+			for j := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[j.index] = BulkResult{Email: j.recipient.Email, Error: err.Error()}
+					continue
+				}
+
+				msg, err := parsed.render(from, j.recipient)
+				if err != nil {
+					results[j.index] = BulkResult{Email: j.recipient.Email, Error: err.Error()}
+					continue
+				}
+				raw, err := msg.Build()
+				if err != nil {
+					results[j.index] = BulkResult{Email: j.recipient.Email, Error: err.Error()}
+					continue
+				}
+
+				attempts := 0
+				backoff := opts.retryBackoff()
+				for {
+					attempts++
+					if s == nil {
+						s, err = c.dial()
+					}
+					if err == nil {
+						err = s.send(from, []string{j.recipient.Email}, raw)
+					}
+
+					if err == nil {
+						results[j.index] = BulkResult{Email: j.recipient.Email, Success: true, Attempts: attempts}
+						break
+					}
+
+					if !isTransient(err) || attempts > opts.MaxRetries {
+						results[j.index] = BulkResult{Email: j.recipient.Email, Attempts: attempts, Error: err.Error()}
+						break
+					}
+
+					// The connection may no longer be usable after an error;
+					// reconnect on the next attempt.
+					if s != nil {
+						s.Close()
+						s = nil
+					}
+					select {
+					case <-time.After(backoff):
+					case <-ctx.Done():
+					}
+					backoff *= 2
+				}
+			}
+		}()
+	}
+
+	for i, r := range recipients {
+		jobs <- job{index: i, recipient: r}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// isTransient reports whether err looks like a 4xx SMTP reply, which
+// indicates the server wants the client to retry rather than give up.
+func isTransient(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal SMTP server for exercising SendBulk without a
+// live mail provider. It doesn't support AUTH or STARTTLS since the tests
+// below don't need them. failFirst, if set, makes RCPT TO for that address
+// fail once with a 450 transient error before succeeding.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	failFirst map[string]bool
+	delivered []string
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: l, failFirst: map[string]bool{}}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+
+	text.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			text.PrintfLine("250 localhost")
+		case strings.HasPrefix(cmd, "RSET"):
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			addr := strings.Trim(line[len("RCPT TO:"):], "<>")
+			s.mu.Lock()
+			shouldFail := s.failFirst[addr]
+			s.failFirst[addr] = false
+			s.mu.Unlock()
+			if shouldFail {
+				text.PrintfLine("450 mailbox temporarily unavailable")
+			} else {
+				text.PrintfLine("250 OK")
+			}
+		case strings.HasPrefix(cmd, "DATA"):
+			text.PrintfLine("354 go ahead")
+			for {
+				l, err := text.ReadLine()
+				if err != nil || l == "." {
+					break
+				}
+			}
+			s.mu.Lock()
+			s.delivered = append(s.delivered, "ok")
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "QUIT"):
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("500 unrecognized command")
+		}
+	}
+}
+
+func newTestClient(addr string) *Client {
+	host, port, _ := net.SplitHostPort(addr)
+	_ = port
+	return NewClient(Config{Host: host, Port: mustAtoi(port), TLSPolicy: TLSNone})
+}
+
+func mustAtoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+func TestSendBulkDelivers(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	client := newTestClient(server.addr())
+
+	recipients := []BulkRecipient{
+		{Email: "a@example.com", Data: map[string]any{"Name": "Alice"}},
+		{Email: "b@example.com", Data: map[string]any{"Name": "Bob"}},
+	}
+	tmpl := BulkTemplate{Subject: "Hi {{.Name}}", Text: "Hello {{.Name}}"}
+
+	results, err := client.SendBulk(context.Background(), "from@example.com", tmpl, recipients, BulkOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("SendBulk: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("result for %s: Success = false, Error = %q", r.Email, r.Error)
+		}
+	}
+}
+
+func TestSendBulkRetriesTransientFailure(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	server.failFirst["c@example.com"] = true
+	client := newTestClient(server.addr())
+
+	recipients := []BulkRecipient{{Email: "c@example.com", Data: nil}}
+	tmpl := BulkTemplate{Subject: "Hi", Text: "Hello"}
+
+	results, err := client.SendBulk(context.Background(), "from@example.com", tmpl, recipients, BulkOptions{
+		Workers:      1,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("SendBulk: %v", err)
+	}
+	if !results[0].Success {
+		t.Fatalf("result.Success = false, want true after retry; Error = %q", results[0].Error)
+	}
+	if results[0].Attempts != 2 {
+		t.Errorf("result.Attempts = %d, want 2", results[0].Attempts)
+	}
+}
+
+func TestSendBulkDryRun(t *testing.T) {
+	client := NewClient(Config{Host: "unused", Port: 0})
+	recipients := []BulkRecipient{{Email: "a@example.com", Data: map[string]any{"Name": "Alice"}}}
+	tmpl := BulkTemplate{Subject: "Hi {{.Name}}", Text: "Hello {{.Name}}"}
+
+	var out bytes.Buffer
+	results, err := client.SendBulk(context.Background(), "from@example.com", tmpl, recipients, BulkOptions{
+		DryRun: true,
+		Output: &out,
+	})
+	if err != nil {
+		t.Fatalf("SendBulk: %v", err)
+	}
+	if !results[0].Success {
+		t.Fatalf("dry run result.Success = false")
+	}
+	if !strings.Contains(out.String(), "Hello Alice") {
+		t.Errorf("dry run output missing rendered body, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,174 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/smtp"
+)
+
+// Config holds the settings needed to connect to an SMTP server.
+type Config struct {
+	// Host is the SMTP server hostname, e.g. "smtp.gmail.com".
+	Host string
+	// Port is the SMTP server port, e.g. 587 for STARTTLS or 465 for
+	// implicit TLS.
+	Port int
+	// Auth authenticates the client to the server. May be nil for servers
+	// that accept unauthenticated mail.
+	Auth smtp.Auth
+
+	// TLSPolicy controls how the connection is secured. Defaults to
+	// TLSOpportunisticStartTLS.
+	TLSPolicy TLSPolicy
+	// ServerName overrides the TLS server name (SNI / certificate
+	// verification). Defaults to Host.
+	ServerName string
+	// InsecureSkipVerify disables certificate verification. Only meant for
+	// testing against servers with self-signed certificates.
+	InsecureSkipVerify bool
+}
+
+// addr returns the "host:port" form expected by net/smtp.
+func (c Config) addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+func (c Config) tlsConfig() *tls.Config {
+	serverName := c.ServerName
+	if serverName == "" {
+		serverName = c.Host
+	}
+	return &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+}
+
+// Client sends Messages through a single SMTP server configuration.
+type Client struct {
+	cfg Config
+}
+
+// NewClient returns a Client that sends through the given server config.
+func NewClient(cfg Config) *Client {
+	return &Client{cfg: cfg}
+}
+
+// Send builds msg and delivers it to all of its recipients (To, Cc and Bcc).
+func (c *Client) Send(msg *Message) error {
+	raw, err := msg.Build()
+	if err != nil {
+		return fmt.Errorf("mail: build message: %w", err)
+	}
+
+	recipients := msg.Recipients()
+
+	s, err := c.dial()
+	if err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+	defer s.Close()
+
+	if err := s.send(msg.From, recipients, raw); err != nil {
+		return fmt.Errorf("mail: send to %v: %w", recipients, err)
+	}
+	return nil
+}
+
+// session is a single authenticated SMTP connection. Unlike Send, which
+// dials once per message, a session can deliver many messages in a row by
+// calling send repeatedly, as SendBulk does.
+type session struct {
+	client *smtp.Client
+}
+
+// dial connects to the server, negotiates TLS per c.cfg.TLSPolicy and
+// authenticates, mirroring the Dial/EHLO/StartTLS/Auth flow from the
+// net/smtp package documentation, with implicit TLS and a mandatory-STARTTLS
+// mode added on top.
+func (c *Client) dial() (*session, error) {
+	var (
+		client *smtp.Client
+		err    error
+	)
+
+	if c.cfg.TLSPolicy == TLSImplicit {
+		conn, dialErr := tls.Dial("tcp", c.cfg.addr(), c.cfg.tlsConfig())
+		if dialErr != nil {
+			return nil, fmt.Errorf("dial implicit tls: %w", dialErr)
+		}
+		client, err = smtp.NewClient(conn, c.cfg.Host)
+	} else {
+		client, err = smtp.Dial(c.cfg.addr())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+
+	if err := client.Hello(""); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ehlo: %w", err)
+	}
+
+	if c.cfg.TLSPolicy == TLSOpportunisticStartTLS || c.cfg.TLSPolicy == TLSMandatoryStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(c.cfg.tlsConfig()); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("starttls: %w", err)
+			}
+		} else if c.cfg.TLSPolicy == TLSMandatoryStartTLS {
+			client.Close()
+			return nil, fmt.Errorf("server does not support STARTTLS")
+		}
+	}
+
+	if c.cfg.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("server does not support AUTH")
+		}
+		if err := client.Auth(c.cfg.Auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	return &session{client: client}, nil
+}
+
+// send delivers raw to recipients over the session's existing connection.
+// It resets any leftover MAIL/RCPT state first so the session can be reused
+// for a subsequent message.
+func (s *session) send(from string, recipients []string, raw []byte) error {
+	if err := s.client.Reset(); err != nil {
+		return fmt.Errorf("reset: %w", err)
+	}
+
+	if err := s.client.Mail(from); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := s.client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("rcpt to %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := s.client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		w.Close()
+		return fmt.Errorf("write data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close data: %w", err)
+	}
+
+	return nil
+}
+
+// Close ends the session with QUIT.
+func (s *session) Close() error {
+	return s.client.Quit()
+}
@@ -0,0 +1,279 @@
+// Package mail builds RFC 5322 / MIME messages and sends them over SMTP.
+//
+// It grew out of the one-shot script in this folder's main.go: instead of
+// hand-concatenating a "From/To/Subject" header blob, Message assembles a
+// proper multipart message (text + HTML alternatives, attachments, extra
+// headers) that can be unit tested without a live SMTP server.
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Attachment is a single file attached to a Message. Data is the raw file
+// content; it is base64-encoded when the message is built.
+type Attachment struct {
+	Filename    string
+	ContentType string // defaults to "application/octet-stream" if empty
+	Data        []byte
+}
+
+// Message describes an email to be sent. Either TextBody, HTMLBody, or both
+// may be set; when both are set they are sent as a multipart/alternative
+// part so the recipient's client can pick the one it prefers.
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+
+	Subject  string
+	TextBody string
+	HTMLBody string
+
+	Attachments []Attachment
+
+	// MessageID, if empty, is generated from the From address and send time.
+	MessageID string
+	// Date, if zero, defaults to time.Now() when the message is built.
+	Date time.Time
+
+	// Headers holds additional headers (e.g. "X-Mailer") merged into the
+	// message. Reserved headers such as From, To and Subject are ignored
+	// here; set the dedicated fields instead.
+	Headers map[string]string
+}
+
+// Recipients returns every address the message is addressed to: To, Cc and
+// Bcc combined. This is what should be passed as the SMTP envelope
+// recipients, since Bcc must never appear in the rendered headers.
+func (m *Message) Recipients() []string {
+	all := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	all = append(all, m.To...)
+	all = append(all, m.Cc...)
+	all = append(all, m.Bcc...)
+	return all
+}
+
+// Build renders the message into an RFC 5322 byte stream suitable for
+// smtp.SendMail's msg argument or the Data stage of a raw SMTP session.
+func (m *Message) Build() ([]byte, error) {
+	if m.From == "" {
+		return nil, fmt.Errorf("mail: message has no From address")
+	}
+	if len(m.To) == 0 {
+		return nil, fmt.Errorf("mail: message has no To recipients")
+	}
+
+	var buf bytes.Buffer
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	headers := textproto.MIMEHeader{}
+	headers.Set("From", m.From)
+	headers.Set("To", strings.Join(m.To, ", "))
+	if len(m.Cc) > 0 {
+		headers.Set("Cc", strings.Join(m.Cc, ", "))
+	}
+	if m.ReplyTo != "" {
+		headers.Set("Reply-To", m.ReplyTo)
+	}
+	headers.Set("Subject", encodeSubject(m.Subject))
+	headers.Set("Date", date.Format(time.RFC1123Z))
+	headers.Set("Message-ID", messageID(m.MessageID, m.From, date))
+	headers.Set("MIME-Version", "1.0")
+
+	for k, v := range m.Headers {
+		switch textproto.CanonicalMIMEHeaderKey(k) {
+		case "From", "To", "Cc", "Bcc", "Reply-To", "Subject", "Date", "Message-Id", "Mime-Version":
+			continue // use the dedicated fields instead
+		}
+		headers.Set(k, v)
+	}
+
+	body, contentType, err := m.buildBody()
+	if err != nil {
+		return nil, err
+	}
+	headers.Set("Content-Type", contentType)
+
+	writeHeaders(&buf, headers)
+	buf.WriteString("\r\n")
+	buf.Write(body)
+
+	return buf.Bytes(), nil
+}
+
+// buildBody renders the text/html/attachment parts and returns the body
+// bytes along with the top-level Content-Type header value to use.
+func (m *Message) buildBody() ([]byte, string, error) {
+	hasText := m.TextBody != ""
+	hasHTML := m.HTMLBody != ""
+
+	if len(m.Attachments) == 0 {
+		switch {
+		case hasText && hasHTML:
+			return m.buildAlternative()
+		case hasHTML:
+			return []byte(m.HTMLBody), "text/html; charset=utf-8", nil
+		default:
+			return []byte(m.TextBody), "text/plain; charset=utf-8", nil
+		}
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	var bodyPart []byte
+	var bodyContentType string
+	var err error
+	switch {
+	case hasText && hasHTML:
+		bodyPart, bodyContentType, err = m.buildAlternative()
+	case hasHTML:
+		bodyPart, bodyContentType = []byte(m.HTMLBody), "text/html; charset=utf-8"
+	default:
+		bodyPart, bodyContentType = []byte(m.TextBody), "text/plain; charset=utf-8"
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	part, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {bodyContentType}})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(bodyPart); err != nil {
+		return nil, "", err
+	}
+
+	for _, a := range m.Attachments {
+		if err := writeAttachment(w, a); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), fmt.Sprintf("multipart/mixed; boundary=%q", w.Boundary()), nil
+}
+
+// buildAlternative renders TextBody and HTMLBody as a multipart/alternative
+// part, text first so that clients without HTML support fall back to it.
+func (m *Message) buildAlternative() ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	text, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := text.Write([]byte(m.TextBody)); err != nil {
+		return nil, "", err
+	}
+
+	html, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=utf-8"}})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := html.Write([]byte(m.HTMLBody)); err != nil {
+		return nil, "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), fmt.Sprintf("multipart/alternative; boundary=%q", w.Boundary()), nil
+}
+
+func writeAttachment(w *multipart.Writer, a Attachment) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(a.Data)))
+	base64.StdEncoding.Encode(encoded, a.Data)
+	// Wrap at 76 columns, as most MIME parsers expect.
+	const lineLen = 76
+	for len(encoded) > 0 {
+		n := lineLen
+		if n > len(encoded) {
+			n = len(encoded)
+		}
+		if _, err := part.Write(encoded[:n]); err != nil {
+			return err
+		}
+		if _, err := part.Write([]byte("\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// encodeSubject applies RFC 2047 Q-encoding to subjects containing non-ASCII
+// text; plain ASCII subjects are returned unchanged.
+func encodeSubject(subject string) string {
+	return mime.QEncoding.Encode("UTF-8", subject)
+}
+
+func messageID(existing, from string, date time.Time) string {
+	if existing != "" {
+		return existing
+	}
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndexByte(addr.Address, '@'); i >= 0 {
+			domain = addr.Address[i+1:]
+		}
+	}
+	return fmt.Sprintf("<%d.%s@%s>", date.UnixNano(), randomToken(date), domain)
+}
+
+// messageIDSeq is a process-wide counter mixed into generated Message-IDs.
+var messageIDSeq atomic.Uint64
+
+// randomToken derives a short token from the send time and a process-wide
+// counter so Message-IDs don't collide within the same nanosecond without
+// pulling in crypto/rand for what is ultimately a non-cryptographic need.
+func randomToken(t time.Time) string {
+	return fmt.Sprintf("%x.%x", t.Nanosecond(), messageIDSeq.Add(1))
+}
+
+func writeHeaders(buf *bytes.Buffer, headers textproto.MIMEHeader) {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+}
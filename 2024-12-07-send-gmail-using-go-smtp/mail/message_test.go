@@ -0,0 +1,127 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageBuildPlainText(t *testing.T) {
+	msg := &Message{
+		From:     "from@example.com",
+		To:       []string{"to@example.com"},
+		Subject:  "Hello",
+		TextBody: "hi there",
+		Date:     time.Date(2024, 12, 7, 10, 0, 0, 0, time.UTC),
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	out := string(raw)
+
+	for _, want := range []string{
+		"From: from@example.com",
+		"To: to@example.com",
+		"Subject: Hello",
+		"Content-Type: text/plain; charset=utf-8",
+		"hi there",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, out)
+		}
+	}
+}
+
+func TestMessageBuildAlternative(t *testing.T) {
+	msg := &Message{
+		From:     "from@example.com",
+		To:       []string{"to@example.com"},
+		Subject:  "Hello",
+		TextBody: "plain body",
+		HTMLBody: "<p>html body</p>",
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	out := string(raw)
+
+	if !strings.Contains(out, "multipart/alternative") {
+		t.Errorf("expected multipart/alternative, got:\n%s", out)
+	}
+	if !strings.Contains(out, "plain body") || !strings.Contains(out, "<p>html body</p>") {
+		t.Errorf("expected both bodies present, got:\n%s", out)
+	}
+}
+
+func TestMessageBuildAttachment(t *testing.T) {
+	msg := &Message{
+		From:     "from@example.com",
+		To:       []string{"to@example.com"},
+		Subject:  "Hello",
+		TextBody: "see attached",
+		Attachments: []Attachment{
+			{Filename: "note.txt", ContentType: "text/plain", Data: []byte("attachment contents")},
+		},
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	out := string(raw)
+
+	for _, want := range []string{
+		"multipart/mixed",
+		`filename="note.txt"`,
+		"Content-Transfer-Encoding: base64",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, out)
+		}
+	}
+}
+
+func TestEncodeSubjectNonASCII(t *testing.T) {
+	got := encodeSubject("héllo")
+	if !strings.HasPrefix(got, "=?UTF-8?") {
+		t.Errorf("expected RFC 2047 encoded subject, got %q", got)
+	}
+}
+
+func TestEncodeSubjectASCII(t *testing.T) {
+	got := encodeSubject("hello")
+	if got != "hello" {
+		t.Errorf("expected ASCII subject unchanged, got %q", got)
+	}
+}
+
+func TestMessageBuildRequiresFromAndTo(t *testing.T) {
+	if _, err := (&Message{To: []string{"to@example.com"}}).Build(); err == nil {
+		t.Error("expected error for missing From")
+	}
+	if _, err := (&Message{From: "from@example.com"}).Build(); err == nil {
+		t.Error("expected error for missing To")
+	}
+}
+
+func TestMessageRecipients(t *testing.T) {
+	msg := &Message{
+		To:  []string{"a@example.com"},
+		Cc:  []string{"b@example.com"},
+		Bcc: []string{"c@example.com"},
+	}
+	got := msg.Recipients()
+	want := []string{"a@example.com", "b@example.com", "c@example.com"}
+	if len(got) != len(want) {
+		t.Fatalf("Recipients() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Recipients()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
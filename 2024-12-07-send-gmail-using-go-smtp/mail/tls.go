@@ -0,0 +1,39 @@
+package mail
+
+import "fmt"
+
+// TLSPolicy controls how a Client secures its connection to the SMTP
+// server.
+type TLSPolicy int
+
+const (
+	// TLSOpportunisticStartTLS upgrades to TLS via STARTTLS when the server
+	// advertises support for it, but continues in plaintext if it doesn't.
+	// This is the zero value, matching the behavior net/smtp.SendMail has
+	// always had.
+	TLSOpportunisticStartTLS TLSPolicy = iota
+	// TLSNone sends over a plaintext connection, even if the server offers
+	// STARTTLS. Only appropriate for servers on trusted networks (e.g.
+	// localhost relays).
+	TLSNone
+	// TLSMandatoryStartTLS upgrades to TLS via STARTTLS and fails rather
+	// than send over a connection the server didn't offer to encrypt.
+	TLSMandatoryStartTLS
+	// TLSImplicit dials straight into TLS, as used on port 465.
+	TLSImplicit
+)
+
+func (p TLSPolicy) String() string {
+	switch p {
+	case TLSNone:
+		return "none"
+	case TLSOpportunisticStartTLS:
+		return "opportunistic-starttls"
+	case TLSMandatoryStartTLS:
+		return "mandatory-starttls"
+	case TLSImplicit:
+		return "implicit-tls"
+	default:
+		return fmt.Sprintf("TLSPolicy(%d)", int(p))
+	}
+}
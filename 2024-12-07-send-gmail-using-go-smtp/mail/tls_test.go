@@ -0,0 +1,17 @@
+package mail
+
+import "testing"
+
+func TestTLSPolicyString(t *testing.T) {
+	cases := map[TLSPolicy]string{
+		TLSOpportunisticStartTLS: "opportunistic-starttls",
+		TLSNone:                  "none",
+		TLSMandatoryStartTLS:     "mandatory-starttls",
+		TLSImplicit:              "implicit-tls",
+	}
+	for policy, want := range cases {
+		if got := policy.String(); got != want {
+			t.Errorf("TLSPolicy(%d).String() = %q, want %q", policy, got, want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// Package mailhandler implements a log/slog.Handler that forwards
+// high-severity records to a recipient list by email, using this module's
+// mail package for delivery.
+//
+// It is the Go/slog take on the SMTPWriter idea from Gogs/Gitea: instead of
+// paging someone for every warning, records are batched and rate-limited
+// into occasional digest emails, with a fallback to stderr so a broken SMTP
+// connection never silently swallows a log record.
+package mailhandler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/dyweb/blog-code/2024-12-07-send-gmail-using-go-smtp/mail"
+)
+
+// defaultSubjectTemplate is used when Options.SubjectTemplate is empty.
+const defaultSubjectTemplate = "[{{.Level}}] {{.Message}}"
+
+// Options configures a Handler.
+type Options struct {
+	// Level is the minimum level forwarded by email. Defaults to
+	// slog.LevelError.
+	Level slog.Leveler
+
+	// BatchSize coalesces up to this many records into a single email.
+	// Defaults to 1 (send as soon as a record arrives).
+	BatchSize int
+	// BatchWindow, if set, flushes a partial batch after this much time has
+	// passed since its first record, even if BatchSize hasn't been reached.
+	BatchWindow time.Duration
+
+	// SubjectKey names a record attribute whose value is used both as the
+	// rate-limit key and as {{.Key}} in SubjectTemplate. If empty, all
+	// records share a single rate-limit bucket and {{.Key}} is empty.
+	SubjectKey string
+	// RateLimit suppresses further emails for the same SubjectKey value
+	// within this interval. Disabled (zero value) by default.
+	RateLimit time.Duration
+
+	// SubjectTemplate is a text/template string executed against a struct
+	// with Level, Message, Key and Count fields. Defaults to
+	// "[{{.Level}}] {{.Message}}".
+	SubjectTemplate string
+
+	// Fallback receives the batch's records, formatted as text, if sending
+	// the email fails. Defaults to os.Stderr.
+	Fallback io.Writer
+}
+
+func (o Options) level() slog.Level {
+	if o.Level == nil {
+		return slog.LevelError
+	}
+	return o.Level.Level()
+}
+
+func (o Options) batchSize() int {
+	if o.BatchSize < 1 {
+		return 1
+	}
+	return o.BatchSize
+}
+
+func (o Options) fallback() io.Writer {
+	if o.Fallback == nil {
+		return os.Stderr
+	}
+	return o.Fallback
+}
+
+// Handler is a slog.Handler that batches matching records and emails them
+// through a mail.Client. Handlers derived from one another via WithAttrs or
+// WithGroup share the same underlying batching state, matching the
+// semantics of the slog.Handler interface.
+type Handler struct {
+	state *state
+
+	attrs  []slog.Attr
+	groups []string
+}
+
+// state is the batching/sending state shared by a Handler and every
+// Handler derived from it via WithAttrs/WithGroup.
+type state struct {
+	opts    Options
+	subject *template.Template
+	client  *mail.Client
+	from    string
+	to      []string
+
+	mu       sync.Mutex
+	buffer   []bufferedRecord
+	timer    *time.Timer
+	lastSent map[string]time.Time
+}
+
+type bufferedRecord struct {
+	time    time.Time
+	level   slog.Level
+	message string
+	attrs   map[string]any
+}
+
+// New returns a Handler that sends through client, from from, to the given
+// recipients.
+func New(client *mail.Client, from string, to []string, opts Options) (*Handler, error) {
+	subjectText := opts.SubjectTemplate
+	if subjectText == "" {
+		subjectText = defaultSubjectTemplate
+	}
+	subject, err := template.New("subject").Parse(subjectText)
+	if err != nil {
+		return nil, fmt.Errorf("mailhandler: parse subject template: %w", err)
+	}
+
+	return &Handler{
+		state: &state{
+			opts:     opts,
+			subject:  subject,
+			client:   client,
+			from:     from,
+			to:       to,
+			lastSent: map[string]time.Time{},
+		},
+	}, nil
+}
+
+// Enabled reports whether level is at or above Options.Level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.state.opts.level()
+}
+
+// Handle buffers r, flushing the batch once it reaches Options.BatchSize or,
+// if this is the first record in a new batch and Options.BatchWindow is set,
+// once that much time has passed.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	// h.attrs already carry the group prefix in effect when they were added.
+	for _, a := range h.attrs {
+		addAttr(attrs, nil, a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(attrs, h.groups, a)
+		return true
+	})
+
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = append(s.buffer, bufferedRecord{
+		time:    r.Time,
+		level:   r.Level,
+		message: r.Message,
+		attrs:   attrs,
+	})
+
+	if len(s.buffer) == 1 && s.opts.BatchWindow > 0 {
+		s.timer = time.AfterFunc(s.opts.BatchWindow, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.flushLocked()
+		})
+	}
+
+	if len(s.buffer) >= s.opts.batchSize() {
+		if s.timer != nil {
+			s.timer.Stop()
+			s.timer = nil
+		}
+		return s.flushLocked()
+	}
+	return nil
+}
+
+// Flush sends any buffered records immediately, bypassing BatchSize and
+// BatchWindow. Callers should call it before shutting down so a partial
+// batch isn't lost.
+func (h *Handler) Flush() error {
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return s.flushLocked()
+}
+
+// flushLocked sends the buffered records as one email. s.mu must be held.
+func (s *state) flushLocked() error {
+	if len(s.buffer) == 0 {
+		return nil
+	}
+	batch := s.buffer
+	s.buffer = nil
+
+	first := batch[0]
+	key := ""
+	if s.opts.SubjectKey != "" {
+		if v, ok := first.attrs[s.opts.SubjectKey]; ok {
+			key = fmt.Sprint(v)
+		}
+	}
+
+	if s.opts.RateLimit > 0 {
+		if last, ok := s.lastSent[key]; ok && time.Since(last) < s.opts.RateLimit {
+			return nil
+		}
+	}
+
+	subject, err := s.renderSubject(first, key, len(batch))
+	if err != nil {
+		return err
+	}
+	body := formatBatch(batch)
+
+	msg := &mail.Message{
+		From:     s.from,
+		To:       s.to,
+		Subject:  subject,
+		TextBody: body,
+	}
+
+	if err := s.client.Send(msg); err != nil {
+		fmt.Fprintf(s.opts.fallback(), "mailhandler: send failed, falling back to stderr: %v\n%s\n", err, body)
+		return err
+	}
+
+	if s.opts.RateLimit > 0 {
+		s.lastSent[key] = time.Now()
+	}
+	return nil
+}
+
+type subjectData struct {
+	Level   string
+	Message string
+	Key     string
+	Count   int
+}
+
+func (s *state) renderSubject(first bufferedRecord, key string, count int) (string, error) {
+	var buf strings.Builder
+	data := subjectData{
+		Level:   first.level.String(),
+		Message: first.message,
+		Key:     key,
+		Count:   count,
+	}
+	if err := s.subject.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("mailhandler: render subject: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func formatBatch(batch []bufferedRecord) string {
+	var buf bytes.Buffer
+	for i, r := range batch {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "[%s] %s: %s\n", r.time.Format(time.RFC3339), r.level, r.message)
+		for k, v := range r.attrs {
+			fmt.Fprintf(&buf, "  %s=%v\n", k, v)
+		}
+	}
+	return buf.String()
+}
+
+func addAttr(dst map[string]any, groups []string, a slog.Attr) {
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
+	dst[key] = a.Value.Any()
+}
+
+// WithAttrs returns a Handler that includes attrs on every subsequent
+// record it forwards.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		clone.attrs = append(clone.attrs, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+	}
+	return &clone
+}
+
+// WithGroup returns a Handler that nests subsequent attributes under name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string{}, h.groups...), name)
+	return &clone
+}
@@ -0,0 +1,194 @@
+package mailhandler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dyweb/blog-code/2024-12-07-send-gmail-using-go-smtp/mail"
+)
+
+// acceptingSMTPServer accepts every message; used to count how many emails
+// a test triggered.
+type acceptingSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	sent     int
+}
+
+func startAcceptingSMTPServer(t *testing.T) *acceptingSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &acceptingSMTPServer{listener: l}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *acceptingSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *acceptingSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(line); {
+		case strings.HasPrefix(cmd, "DATA"):
+			text.PrintfLine("354 go ahead")
+			for {
+				l, err := text.ReadLine()
+				if err != nil || l == "." {
+					break
+				}
+			}
+			s.mu.Lock()
+			s.sent++
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "QUIT"):
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *acceptingSMTPServer) sentCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
+
+func newTestHandler(t *testing.T, addr string, opts Options) *Handler {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(addr)
+	portNum := 0
+	for _, c := range port {
+		portNum = portNum*10 + int(c-'0')
+	}
+	client := mail.NewClient(mail.Config{Host: host, Port: portNum, TLSPolicy: mail.TLSNone})
+	h, err := New(client, "alerts@example.com", []string{"oncall@example.com"}, opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return h
+}
+
+func TestHandlerSendsImmediatelyByDefault(t *testing.T) {
+	server := startAcceptingSMTPServer(t)
+	h := newTestHandler(t, server.listener.Addr().String(), Options{Level: slog.LevelError})
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "disk full", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got := server.sentCount(); got != 1 {
+		t.Errorf("sentCount = %d, want 1", got)
+	}
+}
+
+func TestHandlerBatchesBySize(t *testing.T) {
+	server := startAcceptingSMTPServer(t)
+	h := newTestHandler(t, server.listener.Addr().String(), Options{Level: slog.LevelError, BatchSize: 3})
+
+	for i := 0; i < 2; i++ {
+		r := slog.NewRecord(time.Now(), slog.LevelError, "boom", 0)
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+	}
+	if got := server.sentCount(); got != 0 {
+		t.Fatalf("sentCount after 2/3 = %d, want 0", got)
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "boom", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got := server.sentCount(); got != 1 {
+		t.Errorf("sentCount after 3/3 = %d, want 1", got)
+	}
+}
+
+func TestHandlerRateLimitSuppressesRepeats(t *testing.T) {
+	server := startAcceptingSMTPServer(t)
+	h := newTestHandler(t, server.listener.Addr().String(), Options{
+		Level:      slog.LevelError,
+		SubjectKey: "service",
+		RateLimit:  time.Hour,
+	})
+
+	for i := 0; i < 3; i++ {
+		r := slog.NewRecord(time.Now(), slog.LevelError, "boom", 0)
+		r.AddAttrs(slog.String("service", "checkout"))
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+	}
+	if got := server.sentCount(); got != 1 {
+		t.Errorf("sentCount = %d, want 1 (rest suppressed by rate limit)", got)
+	}
+}
+
+func TestHandlerFallsBackToStderrOnSendFailure(t *testing.T) {
+	// Nothing is listening on this port, so the send must fail.
+	unreachable, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := unreachable.Addr().String()
+	unreachable.Close()
+
+	var fallback bytes.Buffer
+	h := newTestHandler(t, addr, Options{Level: slog.LevelError, Fallback: &fallback})
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "disk full", 0)
+	if err := h.Handle(context.Background(), r); err == nil {
+		t.Fatal("expected Handle to return the send error")
+	}
+	if !strings.Contains(fallback.String(), "disk full") {
+		t.Errorf("fallback output missing record, got:\n%s", fallback.String())
+	}
+}
+
+func TestHandlerWithAttrsSharesState(t *testing.T) {
+	server := startAcceptingSMTPServer(t)
+	h := newTestHandler(t, server.listener.Addr().String(), Options{Level: slog.LevelError, BatchSize: 2})
+	derived := h.WithAttrs([]slog.Attr{slog.String("region", "us-east")})
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "boom", 0)
+	if err := derived.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got := server.sentCount(); got != 0 {
+		t.Fatalf("sentCount = %d, want 0 before second record", got)
+	}
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got := server.sentCount(); got != 1 {
+		t.Errorf("sentCount = %d, want 1 once the shared batch fills up", got)
+	}
+}
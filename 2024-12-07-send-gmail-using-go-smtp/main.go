@@ -1,15 +1,30 @@
 package main
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/smtp"
 	"os"
+
+	"github.com/dyweb/blog-code/2024-12-07-send-gmail-using-go-smtp/mail"
 )
 
 // export GMAIL_APP_PASSWORD=your_app_password
-// go run main.go foo@gmail.com bar@gmail.com
+//
+// Single message:       go run . foo@gmail.com bar@gmail.com
+// Bulk/templated send:  go run . bulk -from foo@gmail.com -recipients list.csv -subject "Hi {{.Name}}" -text body.tmpl
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bulk" {
+		if err := runBulk(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	from := os.Args[1]
 	to := os.Args[2]
 	// Create password at https://myaccount.google.com/apppasswords for foo@gmail.com
@@ -19,29 +34,193 @@ func main() {
 	}
 }
 
-// https://gist.github.com/jpillora/cb46d183eca0710d909a
 func send(from, to, appPassword string) error {
-	auth := smtp.PlainAuth(
-		"",
-		from,
-		appPassword,
-		"smtp.gmail.com",
-	)
-
-	// https://en.wikipedia.org/wiki/Simple_Mail_Transfer_Protocol#SMTP_transport_example
-	subject := "This subject"
-	body := "This first line\n" +
-		"This second line\n" +
-		"Here is a link https://google.com"
-	msg := "From: " + from + "\n" +
-		"To: " + to + "\n" +
-		"Subject: " + subject + "\n\n" +
-		body
-
-	// https://www.cloudflare.com/learning/email-security/smtp-port-25-587/
-	if err := smtp.SendMail("smtp.gmail.com:587", auth, from, []string{to}, []byte(msg)); err != nil {
-		return fmt.Errorf("send email to %s failed: %w", to, err)
+	client := mail.NewClient(mail.Config{
+		Host: "smtp.gmail.com",
+		Port: 587,
+		Auth: smtp.PlainAuth("", from, appPassword, "smtp.gmail.com"),
+	})
+
+	msg := &mail.Message{
+		From:    from,
+		To:      []string{to},
+		Subject: "This subject",
+		TextBody: "This first line\n" +
+			"This second line\n" +
+			"Here is a link https://google.com",
+	}
+
+	if err := client.Send(msg); err != nil {
+		return err
 	}
 	log.Printf("Sent email from %s to %s", from, to)
 	return nil
 }
+
+// runBulk implements the "bulk" subcommand: read a CSV or JSON recipient
+// list, render a text/html template per recipient, and send over a small
+// pool of reused SMTP connections.
+func runBulk(args []string) error {
+	fs := flag.NewFlagSet("bulk", flag.ExitOnError)
+	from := fs.String("from", "", "sender address")
+	recipientsPath := fs.String("recipients", "", "path to a .csv or .json recipient list")
+	subject := fs.String("subject", "", "subject template (text/template syntax)")
+	textPath := fs.String("text", "", "path to a text/template body")
+	htmlPath := fs.String("html", "", "path to an html/template body")
+	workers := fs.Int("workers", 1, "number of SMTP connections to use in parallel")
+	maxRetries := fs.Int("retries", 2, "retries per message on a transient (4xx) reply")
+	dryRun := fs.Bool("dry-run", false, "render messages to stdout instead of sending")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" || *recipientsPath == "" || *subject == "" {
+		return fmt.Errorf("bulk: -from, -recipients and -subject are required")
+	}
+
+	recipients, err := loadRecipients(*recipientsPath)
+	if err != nil {
+		return fmt.Errorf("bulk: %w", err)
+	}
+
+	tmpl := mail.BulkTemplate{Subject: *subject}
+	if *textPath != "" {
+		tmpl.Text, err = readFile(*textPath)
+		if err != nil {
+			return fmt.Errorf("bulk: %w", err)
+		}
+	}
+	if *htmlPath != "" {
+		tmpl.HTML, err = readFile(*htmlPath)
+		if err != nil {
+			return fmt.Errorf("bulk: %w", err)
+		}
+	}
+
+	client := mail.NewClient(mail.Config{
+		Host: "smtp.gmail.com",
+		Port: 587,
+		Auth: smtp.PlainAuth("", *from, os.Getenv("GMAIL_APP_PASSWORD"), "smtp.gmail.com"),
+	})
+
+	results, err := client.SendBulk(context.Background(), *from, tmpl, recipients, mail.BulkOptions{
+		Workers:    *workers,
+		MaxRetries: *maxRetries,
+		DryRun:     *dryRun,
+		Output:     os.Stdout,
+	})
+	if err != nil {
+		return fmt.Errorf("bulk: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	failures := 0
+	for _, r := range results {
+		if !r.Success {
+			failures++
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("bulk: write result log: %w", err)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("bulk: %d of %d messages failed", failures, len(results))
+	}
+	return nil
+}
+
+func readFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// loadRecipients reads a recipient list from path, dispatching on its
+// extension. CSV files must have a header row; the "email" column is used
+// as the address and every other column is exposed to templates under its
+// header name. JSON files hold a list of {"email": ..., other fields...}
+// objects.
+func loadRecipients(path string) ([]mail.BulkRecipient, error) {
+	switch ext := fileExt(path); ext {
+	case ".csv":
+		return loadRecipientsCSV(path)
+	case ".json":
+		return loadRecipientsJSON(path)
+	default:
+		return nil, fmt.Errorf("unsupported recipient list extension %q (want .csv or .json)", ext)
+	}
+}
+
+func fileExt(path string) string {
+	for i := len(path) - 1; i >= 0 && path[i] != '/'; i-- {
+		if path[i] == '.' {
+			return path[i:]
+		}
+	}
+	return ""
+}
+
+func loadRecipientsCSV(path string) ([]mail.BulkRecipient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: empty recipient list", path)
+	}
+
+	header := rows[0]
+	emailCol := -1
+	for i, col := range header {
+		if col == "email" {
+			emailCol = i
+		}
+	}
+	if emailCol == -1 {
+		return nil, fmt.Errorf("%s: missing required \"email\" column", path)
+	}
+
+	recipients := make([]mail.BulkRecipient, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		data := make(map[string]any, len(header))
+		for i, col := range header {
+			if i < len(row) {
+				data[col] = row[i]
+			}
+		}
+		recipients = append(recipients, mail.BulkRecipient{Email: row[emailCol], Data: data})
+	}
+	return recipients, nil
+}
+
+func loadRecipientsJSON(path string) ([]mail.BulkRecipient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []map[string]any
+	if err := json.NewDecoder(f).Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	recipients := make([]mail.BulkRecipient, 0, len(rows))
+	for _, row := range rows {
+		email, _ := row["email"].(string)
+		if email == "" {
+			return nil, fmt.Errorf("%s: recipient missing \"email\" field", path)
+		}
+		recipients = append(recipients, mail.BulkRecipient{Email: email, Data: row})
+	}
+	return recipients, nil
+}